@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// newAdminHandler exposes a JSON REST API for managing mocks and inspecting
+// recently served requests at runtime, so the server can double as a live
+// fixture driven from a test suite rather than only from files on disk.
+func newAdminHandler(server *Server) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /mocks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, server.registry.List())
+	})
+
+	mux.HandleFunc("POST /mocks", func(w http.ResponseWriter, r *http.Request) {
+		var mock MockDefinition
+		if err := json.NewDecoder(r.Body).Decode(&mock); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		created := server.registry.Add(mock)
+		if err := server.rebuildRoutes(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, created)
+	})
+
+	mux.HandleFunc("PUT /mocks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var mock MockDefinition
+		if err := json.NewDecoder(r.Body).Decode(&mock); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		updated, exists := server.registry.Update(r.PathValue("id"), mock)
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		if err := server.rebuildRoutes(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, updated)
+	})
+
+	mux.HandleFunc("DELETE /mocks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !server.registry.Remove(r.PathValue("id")) {
+			http.NotFound(w, r)
+			return
+		}
+		if err := server.rebuildRoutes(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+		if err := server.Reload(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("GET /requests", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, server.requestLog.List())
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("[ERROR] failed to write admin response: %v", err)
+	}
+}
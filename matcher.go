@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// MatchDefinition narrows when a mock's response applies beyond just the
+// endpoint and method. Every non-empty field must match for the mock to
+// fire; an empty MatchDefinition always matches.
+type MatchDefinition struct {
+	Query   map[string]string `json:"query"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body"`
+}
+
+// matchResult reports whether a MatchDefinition matched a request and, if
+// not, which clauses failed so a 404 response can explain the closest miss.
+type matchResult struct {
+	matched bool
+	misses  []string
+}
+
+func (m MatchDefinition) evaluate(r *http.Request) matchResult {
+	result := matchResult{matched: true}
+
+	for key, expected := range m.Query {
+		actual := r.URL.Query().Get(key)
+		if !matchValue(expected, actual) {
+			result.matched = false
+			result.misses = append(result.misses, "query."+key+" = "+actual)
+		}
+	}
+
+	for key, expected := range m.Headers {
+		actual := r.Header.Get(key)
+		if !matchValue(expected, actual) {
+			result.matched = false
+			result.misses = append(result.misses, "header."+key+" = "+actual)
+		}
+	}
+
+	if m.Body != nil {
+		if !matchBody(m.Body, r) {
+			result.matched = false
+			result.misses = append(result.misses, "body")
+		}
+	}
+
+	return result
+}
+
+// matchValue treats expected as a regular expression when it's wrapped in
+// slashes (e.g. "/^ord-\\d+$/"), otherwise as a literal equality check.
+func matchValue(expected, actual string) bool {
+	if len(expected) >= 2 && strings.HasPrefix(expected, "/") && strings.HasSuffix(expected, "/") {
+		pattern := expected[1 : len(expected)-1]
+		matched, err := regexp.MatchString(pattern, actual)
+		return err == nil && matched
+	}
+	return expected == actual
+}
+
+// matchBody checks that expected is a subset of the request's JSON body:
+// every key in expected must be present in the request body with an equal
+// value.
+func matchBody(expected interface{}, r *http.Request) bool {
+	raw, err := readAndRestoreBody(r)
+	if err != nil {
+		return false
+	}
+
+	var actual interface{}
+	if err := json.Unmarshal(raw, &actual); err != nil {
+		return false
+	}
+
+	return isSubset(expected, actual)
+}
+
+// readAndRestoreBody reads r.Body in full and replaces it with a fresh
+// reader over the same bytes, so matchers, templates and the eventual
+// handler can each read the body independently.
+func readAndRestoreBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+	return raw, nil
+}
+
+func isSubset(expected, actual interface{}) bool {
+	switch expectedValue := expected.(type) {
+	case map[string]interface{}:
+		actualMap, ok := actual.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, expectedField := range expectedValue {
+			actualField, exists := actualMap[key]
+			if !exists || !isSubset(expectedField, actualField) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		actualSlice, ok := actual.([]interface{})
+		if !ok || len(actualSlice) != len(expectedValue) {
+			return false
+		}
+		for i, expectedItem := range expectedValue {
+			if !isSubset(expectedItem, actualSlice[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return expected == actual
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ProxyRecorder forwards requests to an upstream server and writes each
+// response to disk as a MockDefinition, so a later run of the server can
+// replay it without the upstream being reachable.
+type ProxyRecorder struct {
+	proxy             *httputil.ReverseProxy
+	mockRootDirectory string
+}
+
+func NewProxyRecorder(upstream string, mockRootDirectory string) (*ProxyRecorder, error) {
+	target, err := url.Parse(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy upstream %q: %w", upstream, err)
+	}
+
+	recorder := &ProxyRecorder{mockRootDirectory: mockRootDirectory}
+
+	reverseProxy := httputil.NewSingleHostReverseProxy(target)
+	reverseProxy.ModifyResponse = recorder.record
+	recorder.proxy = reverseProxy
+
+	return recorder, nil
+}
+
+func (p *ProxyRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.proxy.ServeHTTP(w, r)
+}
+
+// record tees the upstream response: it reads the body to build a mock file
+// on disk, then restores the body so the real response still streams
+// through to the client unchanged.
+func (p *ProxyRecorder) record(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	mock := MockDefinition{
+		Endpoint: resp.Request.URL.Path,
+		Method:   resp.Request.Method,
+		Response: MockResponseDefinition{
+			StatusCode: resp.StatusCode,
+			Headers:    flattenHeader(resp.Header),
+			Body:       decodeBody(body),
+		},
+	}
+
+	if err := writeMockFile(p.mockRootDirectory, mock); err != nil {
+		log.Printf("[ERROR] failed to record mock for %s %s: %v", mock.Method, mock.Endpoint, err)
+	}
+
+	return nil
+}
+
+func flattenHeader(header http.Header) map[string]string {
+	flattened := make(map[string]string, len(header))
+	for key := range header {
+		flattened[key] = header.Get(key)
+	}
+	return flattened
+}
+
+// decodeBody parses the captured body as JSON when possible, so the
+// recorded mock reads naturally as a JSON object rather than an escaped
+// string; non-JSON bodies are kept as plain strings.
+func decodeBody(body []byte) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		return decoded
+	}
+	return string(body)
+}
+
+// writeMockFile saves a recorded mock under <mockRootDirectory>/recorded, so
+// it's picked up by loadMocks (and hot-reloaded) on the next pass.
+func writeMockFile(mockRootDirectory string, mock MockDefinition) error {
+	dir := filepath.Join(mockRootDirectory, "recorded")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("%s_%s_%d.json", strings.ToLower(mock.Method), sanitizeEndpoint(mock.Endpoint), time.Now().UnixNano())
+
+	content, err := json.MarshalIndent(mock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, filename), content, 0o644)
+}
+
+func sanitizeEndpoint(endpoint string) string {
+	trimmed := strings.Trim(endpoint, "/")
+	if trimmed == "" {
+		return "root"
+	}
+	return strings.ReplaceAll(trimmed, "/", "_")
+}
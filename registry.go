@@ -0,0 +1,140 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+func newMockID() string {
+	return uuid.New().String()
+}
+
+// MockRegistry holds the set of mocks currently in effect, each addressable
+// by a stable ID, so the admin API can add/update/remove individual mocks
+// without reloading the whole directory from disk.
+//
+// diskMocks is wholesale-replaced by Replace() on every Reload() (including
+// ones triggered by the fsnotify watcher or a file the proxy/record mode
+// writes). Mutations made through the admin API must survive that, so they
+// live separately: runtimeMocks holds mocks added via Add(), overrides
+// holds admin edits to a disk-loaded mock keyed by its (stable) ID, and
+// removedIDs hides IDs deleted via the admin API until they're re-added.
+type MockRegistry struct {
+	mu           sync.RWMutex
+	diskMocks    []MockDefinition
+	runtimeMocks []MockDefinition
+	overrides    map[string]MockDefinition
+	removedIDs   map[string]bool
+}
+
+func NewMockRegistry() *MockRegistry {
+	return &MockRegistry{
+		overrides:  map[string]MockDefinition{},
+		removedIDs: map[string]bool{},
+	}
+}
+
+// Replace swaps in a freshly loaded set of disk mocks. Runtime additions,
+// overrides and removals made through the admin API are unaffected.
+func (reg *MockRegistry) Replace(mocks []MockDefinition) {
+	reg.mu.Lock()
+	reg.diskMocks = mocks
+	reg.mu.Unlock()
+}
+
+// List returns a snapshot of the currently registered mocks: disk mocks
+// (minus anything removed, with any admin overrides applied) followed by
+// runtime-added mocks.
+func (reg *MockRegistry) List() []MockDefinition {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	mocks := make([]MockDefinition, 0, len(reg.diskMocks)+len(reg.runtimeMocks))
+
+	for _, mock := range reg.diskMocks {
+		if reg.removedIDs[mock.ID] {
+			continue
+		}
+		if override, ok := reg.overrides[mock.ID]; ok {
+			mocks = append(mocks, override)
+			continue
+		}
+		mocks = append(mocks, mock)
+	}
+
+	for _, mock := range reg.runtimeMocks {
+		if reg.removedIDs[mock.ID] {
+			continue
+		}
+		mocks = append(mocks, mock)
+	}
+
+	return mocks
+}
+
+// Add registers a new mock, assigning it a fresh ID regardless of what the
+// caller supplied, and returns the stored copy. Runtime-added mocks are
+// never touched by Replace().
+func (reg *MockRegistry) Add(mock MockDefinition) MockDefinition {
+	mock.ID = newMockID()
+
+	reg.mu.Lock()
+	reg.runtimeMocks = append(reg.runtimeMocks, mock)
+	reg.mu.Unlock()
+
+	return mock
+}
+
+// Update replaces the mock with the given id, keeping the id stable. It
+// reports whether a mock with that id currently exists. Updating a
+// disk-loaded mock records the edit as an override so it survives the next
+// Reload() instead of being clobbered by the on-disk version.
+func (reg *MockRegistry) Update(id string, mock MockDefinition) (MockDefinition, bool) {
+	mock.ID = id
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for i := range reg.runtimeMocks {
+		if reg.runtimeMocks[i].ID == id {
+			reg.runtimeMocks[i] = mock
+			return mock, true
+		}
+	}
+
+	for _, diskMock := range reg.diskMocks {
+		if diskMock.ID == id && !reg.removedIDs[id] {
+			reg.overrides[id] = mock
+			return mock, true
+		}
+	}
+
+	return MockDefinition{}, false
+}
+
+// Remove deletes the mock with the given id, reporting whether it existed.
+// Removing a disk-loaded mock hides it until it's re-added, so it doesn't
+// reappear on the next Reload().
+func (reg *MockRegistry) Remove(id string) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for i := range reg.runtimeMocks {
+		if reg.runtimeMocks[i].ID == id {
+			reg.runtimeMocks = append(reg.runtimeMocks[:i], reg.runtimeMocks[i+1:]...)
+			delete(reg.overrides, id)
+			return true
+		}
+	}
+
+	for _, diskMock := range reg.diskMocks {
+		if diskMock.ID == id {
+			reg.removedIDs[id] = true
+			delete(reg.overrides, id)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// requestLogCapacity bounds how many recent requests the admin API can
+// inspect via GET /requests.
+const requestLogCapacity = 200
+
+// RequestLogEntry records one served request for the admin API's benefit.
+type RequestLogEntry struct {
+	Time          time.Time `json:"time"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	MatchedMockID string    `json:"matchedMockId,omitempty"`
+	StatusCode    int       `json:"statusCode"`
+}
+
+// RequestLog is a bounded ring buffer of the most recently served requests.
+type RequestLog struct {
+	mu      sync.Mutex
+	entries []RequestLogEntry
+	start   int
+	count   int
+}
+
+func NewRequestLog(capacity int) *RequestLog {
+	return &RequestLog{entries: make([]RequestLogEntry, capacity)}
+}
+
+func (l *RequestLog) record(entry RequestLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	index := (l.start + l.count) % len(l.entries)
+	l.entries[index] = entry
+
+	if l.count < len(l.entries) {
+		l.count++
+	} else {
+		l.start = (l.start + 1) % len(l.entries)
+	}
+}
+
+// List returns the logged requests, oldest first.
+func (l *RequestLog) List() []RequestLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]RequestLogEntry, l.count)
+	for i := 0; i < l.count; i++ {
+		result[i] = l.entries[(l.start+i)%len(l.entries)]
+	}
+	return result
+}
+
+// matchedMockIDContextKey stores a *string in the request context that the
+// matching mock handler fills in, so the top-level dispatcher can log which
+// mock (if any) served the request without threading it through every call.
+const matchedMockIDContextKey contextKey = "matchedMockID"
+
+func matchedMockIDSlot(r *http.Request) *string {
+	if slot, ok := r.Context().Value(matchedMockIDContextKey).(*string); ok {
+		return slot
+	}
+	return nil
+}
+
+// statusRecorder wraps a ResponseWriter to capture the status code written,
+// while still passing through Hijack/Flush so simulate's drop and
+// throughput features keep working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(body []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(body)
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// patternSegment is one "/"-separated piece of a compiled endpoint pattern.
+// Exactly one of literal/isParam/isWildcard applies.
+type patternSegment struct {
+	literal    string
+	isParam    bool
+	isWildcard bool
+	name       string
+}
+
+func compilePattern(raw string) []patternSegment {
+	parts := strings.Split(strings.Trim(raw, "/"), "/")
+	segments := make([]patternSegment, len(parts))
+
+	for i, part := range parts {
+		switch {
+		case part == "*":
+			segments[i] = patternSegment{isWildcard: true}
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && len(part) > 2:
+			segments[i] = patternSegment{isParam: true, name: part[1 : len(part)-1]}
+		default:
+			segments[i] = patternSegment{literal: part}
+		}
+	}
+
+	return segments
+}
+
+// matchPattern checks path against a compiled pattern. It returns the
+// extracted path params and a specificity score (higher means more
+// specific) so the router can prefer, e.g., "/users/me" over "/users/{id}"
+// when both match.
+func matchPattern(segments []patternSegment, path string) (map[string]string, int, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	params := map[string]string{}
+	score := 0
+
+	for i, seg := range segments {
+		if seg.isWildcard && i == len(segments)-1 {
+			if i > len(parts) {
+				return nil, 0, false
+			}
+			params["*"] = strings.Join(parts[i:], "/")
+			score++
+			return params, score, true
+		}
+
+		if i >= len(parts) {
+			return nil, 0, false
+		}
+
+		switch {
+		case seg.isWildcard:
+			score++
+		case seg.isParam:
+			params[seg.name] = parts[i]
+			score += 2
+		default:
+			if seg.literal != parts[i] {
+				return nil, 0, false
+			}
+			score += 3
+		}
+	}
+
+	if len(segments) != len(parts) {
+		return nil, 0, false
+	}
+
+	return params, score, true
+}
+
+type routerEntry struct {
+	pattern  string
+	segments []patternSegment
+	chain    RouteChain
+}
+
+// Router dispatches requests to the RouteChain registered for the most
+// specific matching endpoint pattern, extracting any {name}/wildcard
+// segments into the request context along the way.
+type Router struct {
+	entries []routerEntry
+	// NotFound, if set, handles requests that match no registered pattern
+	// (e.g. a proxy/record fallback) instead of a plain 404.
+	NotFound http.Handler
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+func (router *Router) Handle(pattern string, chain RouteChain) {
+	router.entries = append(router.entries, routerEntry{
+		pattern:  pattern,
+		segments: compilePattern(pattern),
+		chain:    chain,
+	})
+}
+
+// patternMatch is a registered pattern that matched the request path, kept
+// alongside its extracted params and specificity score while the router
+// looks for one that can actually serve the request's method/matchers.
+type patternMatch struct {
+	entry  *routerEntry
+	params map[string]string
+	score  int
+}
+
+func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var matches []patternMatch
+
+	for i := range router.entries {
+		entry := &router.entries[i]
+		params, score, ok := matchPattern(entry.segments, r.URL.Path)
+		if ok {
+			matches = append(matches, patternMatch{entry: entry, params: params, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	// A more specific pattern (e.g. "/users/me") can match the path but have
+	// no middleware for this method or matcher, in which case we must fall
+	// through to the next-best pattern (e.g. "/users/{id}") rather than
+	// 404ing on the first match.
+	for _, match := range matches {
+		if !chainCanServe(match.entry.chain, r) {
+			continue
+		}
+		ctx := context.WithValue(r.Context(), pathParamsContextKey, match.params)
+		match.entry.chain.Handler(w, r.WithContext(ctx))
+		return
+	}
+
+	// No pattern had a middleware willing to serve this request. That's not
+	// the same as no mock existing for this request at all, so a configured
+	// fallback (e.g. the proxy/record handler) still takes priority over a
+	// matched pattern's own diagnostic 404 -- otherwise a path that merely
+	// collides with a mock's endpoint under a different method never gets
+	// proxied.
+	if router.NotFound != nil {
+		router.NotFound.ServeHTTP(w, r)
+		return
+	}
+
+	// No fallback configured: defer to the most specific matched pattern's
+	// own handler so callers get its diagnostic 404 body instead of a bare
+	// one.
+	if len(matches) > 0 {
+		best := matches[0]
+		ctx := context.WithValue(r.Context(), pathParamsContextKey, best.params)
+		best.entry.chain.Handler(w, r.WithContext(ctx))
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// chainCanServe reports whether any middleware registered on chain would
+// actually handle r, i.e. its method and matcher both apply.
+func chainCanServe(chain RouteChain, r *http.Request) bool {
+	for _, middleware := range chain.Middleware {
+		if middleware.Method == r.Method && middleware.Match.evaluate(r).matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchPatternSpecificityOrdering(t *testing.T) {
+	literal, _, ok := matchPattern(compilePattern("/users/me"), "/users/me")
+	if !ok {
+		t.Fatalf("expected literal pattern to match")
+	}
+
+	param, _, ok := matchPattern(compilePattern("/users/{id}"), "/users/me")
+	if !ok {
+		t.Fatalf("expected param pattern to match")
+	}
+
+	wildcard, _, ok := matchPattern(compilePattern("/users/*"), "/users/me")
+	if !ok {
+		t.Fatalf("expected wildcard pattern to match")
+	}
+
+	_, literalScore, _ := matchPattern(compilePattern("/users/me"), "/users/me")
+	_, paramScore, _ := matchPattern(compilePattern("/users/{id}"), "/users/me")
+	_, wildcardScore, _ := matchPattern(compilePattern("/users/*"), "/users/me")
+
+	if !(literalScore > paramScore && paramScore > wildcardScore) {
+		t.Fatalf("expected literal > param > wildcard specificity, got %d, %d, %d", literalScore, paramScore, wildcardScore)
+	}
+
+	if literal == nil || param["id"] != "me" || wildcard["*"] != "me" {
+		t.Fatalf("unexpected extracted params: literal=%v param=%v wildcard=%v", literal, param, wildcard)
+	}
+}
+
+func handlerReturning(status int) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}
+}
+
+func TestRouterServeHTTPPrefersMostSpecificMatch(t *testing.T) {
+	router := NewRouter()
+	router.Handle("/users/{id}", RouteChain{
+		Handler: handlerReturning(http.StatusOK),
+		Middleware: []ChainMiddleware{
+			{Method: http.MethodGet, Match: MatchDefinition{}},
+		},
+	})
+	router.Handle("/users/me", RouteChain{
+		Handler: handlerReturning(http.StatusTeapot),
+		Middleware: []ChainMiddleware{
+			{Method: http.MethodGet, Match: MatchDefinition{}},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("expected the more specific literal pattern to win, got status %d", rec.Code)
+	}
+}
+
+func TestRouterServeHTTPFallsThroughWhenBestMatchCannotServe(t *testing.T) {
+	router := NewRouter()
+	router.Handle("/users/me", RouteChain{
+		Handler: handlerReturning(http.StatusTeapot),
+		Middleware: []ChainMiddleware{
+			{Method: http.MethodPost, Match: MatchDefinition{}},
+		},
+	})
+	router.Handle("/users/{id}", RouteChain{
+		Handler: handlerReturning(http.StatusOK),
+		Middleware: []ChainMiddleware{
+			{Method: http.MethodGet, Match: MatchDefinition{}},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected fallback to the less specific pattern, got status %d", rec.Code)
+	}
+}
+
+func TestRouterServeHTTPPrefersNotFoundOverUnservableMatch(t *testing.T) {
+	router := NewRouter()
+	router.Handle("/users/{id}", RouteChain{
+		Handler: handlerReturning(http.StatusTeapot),
+		Middleware: []ChainMiddleware{
+			{Method: http.MethodGet, Match: MatchDefinition{}},
+		},
+	})
+	router.NotFound = http.HandlerFunc(handlerReturning(http.StatusBadGateway))
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected NotFound fallback to take priority over the matched pattern's own handler, got status %d", rec.Code)
+	}
+}
+
+func TestRouterServeHTTPFallsBackToMatchedPatternWhenNoNotFoundConfigured(t *testing.T) {
+	router := NewRouter()
+	router.Handle("/users/{id}", RouteChain{
+		Handler: handlerReturning(http.StatusTeapot),
+		Middleware: []ChainMiddleware{
+			{Method: http.MethodGet, Match: MatchDefinition{}},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("expected fallback to the matched pattern's own handler, got status %d", rec.Code)
+	}
+}
+
+func TestRouterServeHTTPPlain404WhenNothingMatches(t *testing.T) {
+	router := NewRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/totally/unknown", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected a plain 404, got status %d", rec.Code)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// SequenceDefinition lets a mock walk through an ordered list of responses
+// as it's repeatedly hit, the canonical pattern for scripting a flow like
+// "first call 202 pending, next call 200 done".
+type SequenceDefinition struct {
+	Responses []MockResponseDefinition `json:"responses"`
+	// OnExhaust controls what happens once every response has been served
+	// once: "repeat-last" (default), "wrap", or "404".
+	OnExhaust string `json:"onExhaust"`
+	// ResetOn names a header or query parameter whose mere presence on a
+	// request zeroes the sequence counter, e.g. a fresh test run token.
+	ResetOn string `json:"resetOn"`
+}
+
+func handleSequencedResponse(mock MockDefinition) func(w http.ResponseWriter, r *http.Request, next func(error)) {
+	responses := make([]compiledResponse, len(mock.Sequence.Responses))
+	for i, response := range mock.Sequence.Responses {
+		responses[i] = compileResponse(mock, response)
+	}
+
+	var counter atomic.Int64
+
+	return func(w http.ResponseWriter, r *http.Request, next func(error)) {
+		if len(responses) == 0 {
+			writeNoMatchResponse(w, r, []string{"sequence has no responses configured"})
+			return
+		}
+
+		if resetOn := mock.Sequence.ResetOn; resetOn != "" {
+			if r.Header.Get(resetOn) != "" || r.URL.Query().Get(resetOn) != "" {
+				counter.Store(0)
+			}
+		}
+
+		index := int(counter.Add(1)) - 1
+
+		if index < len(responses) {
+			serveCompiledResponse(mock, responses[index], w, r)
+			return
+		}
+
+		switch mock.Sequence.OnExhaust {
+		case "wrap":
+			serveCompiledResponse(mock, responses[index%len(responses)], w, r)
+		case "404":
+			writeNoMatchResponse(w, r, []string{"sequence exhausted"})
+		default:
+			serveCompiledResponse(mock, responses[len(responses)-1], w, r)
+		}
+	}
+}
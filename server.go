@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
 )
 
 const (
@@ -19,19 +25,26 @@ const (
 var (
 	mockRootDirectory = flag.String("mocks", "mocks", "reads mock files from this directory")
 	port              = flag.Int("port", 9000, "mock server port")
-	routeChain        = map[string]RouteChain{}
+	drainTimeout      = flag.Duration("drain-timeout", 10*time.Second, "time to wait for in-flight requests to finish before shutting down")
+	adminPort         = flag.Int("admin-port", 0, "if set, serves a JSON admin API for managing mocks on this port")
+	proxyUpstream     = flag.String("proxy", "", "if set, unmatched requests are forwarded to this upstream URL and recorded as mock files")
+	recordOnly        = flag.Bool("record-only", false, "always proxy and record, ignoring any loaded mocks (requires -proxy)")
 )
 
 type MockDefinition struct {
+	ID       string                 `json:"id,omitempty"`
 	Endpoint string                 `json:"endpoint"`
 	Method   string                 `json:"method"`
+	Match    MatchDefinition        `json:"match"`
 	Response MockResponseDefinition `json:"response"`
+	Sequence *SequenceDefinition    `json:"sequence"`
 }
 
 type MockResponseDefinition struct {
-	Body       interface{}       `json:"body"`
-	Headers    map[string]string `json:"headers"`
-	StatusCode int               `json:"statusCode"`
+	Body       interface{}        `json:"body"`
+	Headers    map[string]string  `json:"headers"`
+	StatusCode int                `json:"statusCode"`
+	Simulate   SimulateDefinition `json:"simulate"`
 }
 
 type RouteChain struct {
@@ -41,41 +54,207 @@ type RouteChain struct {
 
 type ChainMiddleware struct {
 	Method  string
+	Match   MatchDefinition
 	Handler func(w http.ResponseWriter, r *http.Request, next func(error))
 }
 
-func main() {
-	flag.Parse()
+// Server owns the route chain currently serving requests. Reload rebuilds
+// the chain from disk and swaps it in atomically, so in-flight requests
+// keep running against the snapshot they started with.
+type Server struct {
+	mu                sync.RWMutex
+	router            *Router
+	mockRootDirectory string
+	registry          *MockRegistry
+	requestLog        *RequestLog
+	proxy             http.Handler
+	recordOnly        bool
+}
+
+// SetProxy configures unmatched (or, with recordOnly, all) requests to be
+// forwarded to proxy and recorded as mock files, then rebuilds the route
+// chain so the new fallback takes effect immediately.
+func (s *Server) SetProxy(proxy http.Handler, recordOnly bool) error {
+	s.mu.Lock()
+	s.proxy = proxy
+	s.recordOnly = recordOnly
+	s.mu.Unlock()
+
+	return s.rebuildRoutes()
+}
 
-	log.Printf("loading mocks from '%s'", *mockRootDirectory)
+func NewServer(mockRootDirectory string) (*Server, error) {
+	s := &Server{
+		mockRootDirectory: mockRootDirectory,
+		registry:          NewMockRegistry(),
+		requestLog:        NewRequestLog(requestLogCapacity),
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the mock directory from disk, replacing the registry
+// wholesale, then rebuilds the route chain from it.
+func (s *Server) Reload() error {
+	log.Printf("loading mocks from '%s'", s.mockRootDirectory)
 
-	mocks, err := loadMocks(*mockRootDirectory)
+	mocks, err := loadMocks(s.mockRootDirectory)
 	if err != nil {
-		log.Fatalf("failed to load mocks: %v", err)
+		return fmt.Errorf("failed to load mocks: %w", err)
 	}
 
+	s.registry.Replace(mocks)
+	return s.rebuildRoutes()
+}
+
+// rebuildRoutes regenerates the route chain from the registry's current
+// mocks and swaps it in for the one currently serving requests.
+func (s *Server) rebuildRoutes() error {
 	log.Println("generating routes")
+	router := buildRouteChain(s.registry.List(), s.proxy)
+
+	s.mu.Lock()
+	s.router = router
+	s.mu.Unlock()
+
+	return nil
+}
 
-	mux := http.NewServeMux()
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	router := s.router
+	recordOnly := s.recordOnly
+	proxy := s.proxy
+	s.mu.RUnlock()
+
+	matchedMockID := new(string)
+	ctx := context.WithValue(r.Context(), matchedMockIDContextKey, matchedMockID)
+	r = r.WithContext(ctx)
+
+	recorder := &statusRecorder{ResponseWriter: w}
+	if recordOnly && proxy != nil {
+		proxy.ServeHTTP(recorder, r)
+	} else {
+		router.ServeHTTP(recorder, r)
+	}
+
+	s.requestLog.record(RequestLogEntry{
+		Time:          time.Now(),
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		MatchedMockID: *matchedMockID,
+		StatusCode:    recorder.status,
+	})
+}
+
+func buildRouteChain(mocks []MockDefinition, notFound http.Handler) *Router {
+	routeChain := map[string]RouteChain{}
+	// Registration order must be deterministic: when two endpoint patterns
+	// are equally specific for the same path (e.g. "/users/{id}" and
+	// "/users/{name}"), Router.ServeHTTP breaks the tie by whichever was
+	// registered first. Track first-seen order here instead of ranging over
+	// routeChain directly, since Go map iteration order is randomized.
+	endpointOrder := make([]string, 0, len(mocks))
+	router := NewRouter()
+	router.NotFound = notFound
 
 	for _, mock := range mocks {
-		handler := generateMockHandler(mock)
-		if handler != nil {
-			mux.HandleFunc(mock.Endpoint, handler)
+		if _, exists := routeChain[mock.Endpoint]; !exists {
+			endpointOrder = append(endpointOrder, mock.Endpoint)
+		}
+		generateMockHandler(routeChain, mock)
+	}
+
+	for _, endpoint := range endpointOrder {
+		router.Handle(endpoint, routeChain[endpoint])
+	}
+
+	return router
+}
+
+func main() {
+	flag.Parse()
+
+	server, err := NewServer(*mockRootDirectory)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if *proxyUpstream != "" {
+		proxyRecorder, err := NewProxyRecorder(*proxyUpstream, *mockRootDirectory)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := server.SetProxy(proxyRecorder, *recordOnly); err != nil {
+			log.Fatalf("failed to enable proxy: %v", err)
+		}
+		log.Printf("proxying unmatched requests to %s (record-only: %v)", *proxyUpstream, *recordOnly)
+	} else if *recordOnly {
+		log.Fatalf("-record-only requires -proxy to be set")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher, err := watchMocks(ctx, server)
+	if err != nil {
+		log.Fatalf("failed to watch mock directory: %v", err)
+	}
+	defer watcher.Close()
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%v", *port),
+		Handler: server,
+	}
+
+	go func() {
+		log.Printf("server running at 0.0.0.0:%d", *port)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server error: %v", err)
+		}
+	}()
+
+	var adminServer *http.Server
+	if *adminPort != 0 {
+		adminServer = &http.Server{
+			Addr:    fmt.Sprintf(":%v", *adminPort),
+			Handler: newAdminHandler(server),
 		}
+
+		go func() {
+			log.Printf("admin API running at 0.0.0.0:%d", *adminPort)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("admin server error: %v", err)
+			}
+		}()
 	}
 
-	log.Printf("server running at 0.0.0.0:%d", *port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%v", *port), mux))
+	<-ctx.Done()
+	log.Println("shutting down, draining in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *drainTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[ERROR] graceful shutdown failed: %v", err)
+	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[ERROR] admin server graceful shutdown failed: %v", err)
+		}
+	}
 }
 
-func generateMockHandler(mock MockDefinition) func(w http.ResponseWriter, request *http.Request) {
+func generateMockHandler(routeChain map[string]RouteChain, mock MockDefinition) {
 	log.Printf("generating handler for %s %s", mock.Method, mock.Endpoint)
 
 	chain, exist := routeChain[mock.Endpoint]
 	if exist {
 		chain.Middleware = append(chain.Middleware, ChainMiddleware{
 			Method:  mock.Method,
+			Match:   mock.Match,
 			Handler: handleMockResponse(mock),
 		})
 
@@ -84,12 +263,13 @@ func generateMockHandler(mock MockDefinition) func(w http.ResponseWriter, reques
 			Middleware: chain.Middleware,
 		}
 
-		return nil
+		return
 	}
 
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		middlewares := routeChain[mock.Endpoint].Middleware
 		currentMidleware := -1
+		var closestMisses []string
 
 		var next func(error)
 
@@ -103,22 +283,25 @@ func generateMockHandler(mock MockDefinition) func(w http.ResponseWriter, reques
 			currentMidleware = currentMidleware + 1
 
 			if currentMidleware >= len(middlewares) {
-				w.WriteHeader(404)
-			} else if r.Method == middlewares[currentMidleware].Method {
-				middleware := middlewares[currentMidleware]
-				middleware.Handler(w, r, next)
+				writeNoMatchResponse(w, r, closestMisses)
+			} else if r.Method != middlewares[currentMidleware].Method {
+				next(nil)
+			} else if result := middlewares[currentMidleware].Match.evaluate(r); result.matched {
+				middlewares[currentMidleware].Handler(w, r, next)
 			} else {
+				closestMisses = append(closestMisses, result.misses...)
 				next(nil)
 			}
 		}
 
 		next(nil)
-		log.Printf("%s %s", r.Method, r.Pattern)
+		log.Printf("%s %s", r.Method, r.URL.Path)
 	}
 
 	chainMiddleware := make([]ChainMiddleware, 0)
 	chainMiddleware = append(chainMiddleware, ChainMiddleware{
 		Method:  mock.Method,
+		Match:   mock.Match,
 		Handler: handleMockResponse(mock),
 	})
 
@@ -126,34 +309,115 @@ func generateMockHandler(mock MockDefinition) func(w http.ResponseWriter, reques
 		Handler:    handler,
 		Middleware: chainMiddleware,
 	}
+}
 
-	return handler
+// writeNoMatchResponse is called when every registered mock for an endpoint
+// either had the wrong method or failed its matcher. It returns 404 along
+// with a diagnostic body listing the matcher clauses that came closest,
+// which saves guessing why a mock didn't fire.
+func writeNoMatchResponse(w http.ResponseWriter, r *http.Request, misses []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"error":   "no mock matched this request",
+		"path":    r.URL.Path,
+		"method":  r.Method,
+		"closest": misses,
+	})
+	if err != nil {
+		log.Printf("[ERROR] failed to marshal no-match diagnostic: %v", err)
+		return
+	}
+	w.Write(body)
 }
 
-func handleMockResponse(mock MockDefinition) func(w http.ResponseWriter, r *http.Request, next func(error)) {
-	statusCode := mock.Response.StatusCode
+// compiledResponse is a MockResponseDefinition with its load-time work
+// (status default, body serialization, template parsing) already done, so
+// serving it per request is just a lookup and a write.
+type compiledResponse struct {
+	statusCode       int
+	headers          map[string]string
+	serializedBody   []byte
+	responseTemplate *template.Template
+	simulate         SimulateDefinition
+}
+
+func compileResponse(mock MockDefinition, response MockResponseDefinition) compiledResponse {
+	statusCode := response.StatusCode
 	if statusCode == 0 {
 		statusCode = 200
 	}
 
-	serializedBody := serialize(mock.Response.Body)
+	serializedBody := serialize(response.Body)
 
-	return func(w http.ResponseWriter, r *http.Request, next func(error)) {
-		headers := map[string]string{
-			"Content-Type": "application/json",
-			"Server":       SERVER_NAME,
+	var responseTemplate *template.Template
+	if isTemplatedBody(serializedBody) {
+		tmpl, err := parseResponseTemplate(mock, serializedBody)
+		if err != nil {
+			log.Printf("[ERROR] %s %s: failed to parse response template, serving it statically: %v", mock.Method, mock.Endpoint, err)
+		} else {
+			responseTemplate = tmpl
 		}
+	}
 
-		for key, value := range mock.Response.Headers {
-			headers[key] = value
-		}
+	return compiledResponse{
+		statusCode:       statusCode,
+		headers:          response.Headers,
+		serializedBody:   serializedBody,
+		responseTemplate: responseTemplate,
+		simulate:         response.Simulate,
+	}
+}
+
+func serveCompiledResponse(mock MockDefinition, compiled compiledResponse, w http.ResponseWriter, r *http.Request) {
+	if slot := matchedMockIDSlot(r); slot != nil {
+		*slot = mock.ID
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"Server":       SERVER_NAME,
+	}
+
+	for key, value := range compiled.headers {
+		headers[key] = value
+	}
+
+	for key, value := range headers {
+		w.Header().Add(key, value)
+	}
+
+	if compiled.simulate.apply(w, r) {
+		return
+	}
 
-		for key, value := range headers {
-			w.Header().Add(key, value)
+	body := compiled.serializedBody
+	if compiled.responseTemplate != nil {
+		rendered, err := renderResponseTemplate(compiled.responseTemplate, r)
+		if err != nil {
+			log.Printf("[ERROR] %s %s: failed to render response template: %v", mock.Method, mock.Endpoint, err)
+			w.WriteHeader(500)
+			return
 		}
+		body = rendered
+	}
 
-		w.WriteHeader(statusCode)
-		w.Write(serializedBody)
+	w.WriteHeader(compiled.statusCode)
+	if err := writeThrottled(w, r, body, compiled.simulate.ThroughputBps); err != nil {
+		log.Printf("[ERROR] %s %s: failed to write response body: %v", mock.Method, mock.Endpoint, err)
+	}
+}
+
+func handleMockResponse(mock MockDefinition) func(w http.ResponseWriter, r *http.Request, next func(error)) {
+	if mock.Sequence != nil {
+		return handleSequencedResponse(mock)
+	}
+
+	compiled := compileResponse(mock, mock.Response)
+
+	return func(w http.ResponseWriter, r *http.Request, next func(error)) {
+		serveCompiledResponse(mock, compiled, w, r)
 	}
 }
 
@@ -219,6 +483,7 @@ func loadMockFromJson(filepath string) ([]MockDefinition, error) {
 	var listOfMocks []MockDefinition
 	err = json.Unmarshal(content, &listOfMocks)
 	if err == nil {
+		assignDiskMockIDs(filepath, listOfMocks)
 		return listOfMocks, nil
 	}
 
@@ -227,8 +492,19 @@ func loadMockFromJson(filepath string) ([]MockDefinition, error) {
 	if err != nil {
 		return nil, err
 	}
+	mocks := []MockDefinition{mock}
+	assignDiskMockIDs(filepath, mocks)
 
-	return []MockDefinition{mock}, nil
+	return mocks, nil
+}
+
+// assignDiskMockIDs stamps each mock loaded from filepath with an ID
+// derived from its file path and position within it, so the same mock gets
+// the same ID across reloads instead of a fresh random one every time.
+func assignDiskMockIDs(filepath string, mocks []MockDefinition) {
+	for i := range mocks {
+		mocks[i].ID = fmt.Sprintf("%s#%d", filepath, i)
+	}
 }
 
 // func validateMock(mock MockDefinition) error {}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// delayRange describes how long to hold a response before serving it. A
+// plain JSON number (milliseconds) sets a fixed delay; {"min":.., "max":..}
+// (also milliseconds) picks a random delay in that range on every request.
+type delayRange struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (d *delayRange) UnmarshalJSON(data []byte) error {
+	var fixed float64
+	if err := json.Unmarshal(data, &fixed); err == nil {
+		d.Min = time.Duration(fixed) * time.Millisecond
+		d.Max = d.Min
+		return nil
+	}
+
+	var ranged struct {
+		Min float64 `json:"min"`
+		Max float64 `json:"max"`
+	}
+	if err := json.Unmarshal(data, &ranged); err != nil {
+		return fmt.Errorf("delay must be a number of milliseconds or a {min,max} object: %w", err)
+	}
+	d.Min = time.Duration(ranged.Min) * time.Millisecond
+	d.Max = time.Duration(ranged.Max) * time.Millisecond
+	return nil
+}
+
+func (d delayRange) next() time.Duration {
+	if d.Max <= d.Min {
+		return d.Min
+	}
+	return d.Min + time.Duration(rand.Int63n(int64(d.Max-d.Min)))
+}
+
+// SimulateDefinition lets a mock emulate an unreliable or slow upstream, so
+// clients can be tested against retries, timeouts and backpressure without
+// standing up real infrastructure.
+type SimulateDefinition struct {
+	Delay         delayRange `json:"delay"`
+	FailRate      float64    `json:"failRate"`
+	FailStatus    int        `json:"failStatus"`
+	DropRate      float64    `json:"dropRate"`
+	ThroughputBps int64      `json:"throughputBps"`
+}
+
+// apply sleeps, drops or fails the connection according to the simulate
+// definition. It returns true if it fully handled the response (drop or
+// failure) and the caller should not continue serving the mock body.
+func (s SimulateDefinition) apply(w http.ResponseWriter, r *http.Request) (handled bool) {
+	if s.Delay.Max > 0 || s.Delay.Min > 0 {
+		delay := s.Delay.next()
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	if s.DropRate > 0 && rand.Float64() < s.DropRate {
+		hijackConnection(w)
+		return true
+	}
+
+	if s.FailRate > 0 && rand.Float64() < s.FailRate {
+		status := s.FailStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+		return true
+	}
+
+	return false
+}
+
+// hijackConnection closes the underlying TCP connection without writing a
+// response, simulating a dropped upstream.
+func hijackConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		log.Printf("[ERROR] dropRate configured but ResponseWriter does not support hijacking")
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		log.Printf("[ERROR] failed to hijack connection: %v", err)
+		return
+	}
+	conn.Close()
+}
+
+// writeThrottled writes body to w in chunks sized to throughputBps, sleeping
+// between chunks so the client observes that transfer rate.
+func writeThrottled(w http.ResponseWriter, r *http.Request, body []byte, throughputBps int64) error {
+	if throughputBps <= 0 {
+		_, err := w.Write(body)
+		return err
+	}
+
+	flusher, _ := w.(http.Flusher)
+	const tick = 100 * time.Millisecond
+	chunkSize := int64(float64(throughputBps) * tick.Seconds())
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	reader := bytes.NewReader(body)
+	buf := make([]byte, chunkSize)
+
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(tick):
+		case <-r.Context().Done():
+			return r.Context().Err()
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type contextKey string
+
+// pathParamsContextKey is where the router stashes extracted {name} path
+// segments for a dispatched request, read back by templates and handlers.
+const pathParamsContextKey contextKey = "pathParams"
+
+// templateContext is the data exposed to a templated response body. It is
+// rebuilt for every request so mocks can echo back request state.
+type templateContext struct {
+	Path       string
+	PathParams map[string]string
+	Query      map[string][]string
+	Headers    map[string][]string
+	Body       interface{}
+}
+
+var templateFuncs = template.FuncMap{
+	"uuid":    func() string { return uuid.New().String() },
+	"now":     func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"randInt": randInt,
+	"faker":   func() fakerHelpers { return fakerHelpers{} },
+}
+
+// fakerFuncs backs the `faker.*` helpers referenced from response templates,
+// e.g. {{faker.Name}} or {{faker.Email}}. It's a small self-contained
+// generator rather than a pulled-in dependency, since mocks only need
+// plausible-looking values, not a statistically rigorous one.
+type fakerHelpers struct{}
+
+func (fakerHelpers) Name() string {
+	first := []string{"Alex", "Jordan", "Taylor", "Morgan", "Casey", "Riley"}
+	last := []string{"Smith", "Johnson", "Lee", "Brown", "Garcia", "Patel"}
+	return first[rand.Intn(len(first))] + " " + last[rand.Intn(len(last))]
+}
+
+func (fakerHelpers) Email() string {
+	return fmt.Sprintf("user%d@example.com", rand.Intn(1_000_000))
+}
+
+func (f fakerHelpers) UUID() string {
+	return uuid.New().String()
+}
+
+func randInt(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + rand.Intn(max-min)
+}
+
+// isTemplatedBody reports whether a serialized response body contains
+// template expressions and therefore needs per-request rendering instead of
+// being served as a static byte slice.
+func isTemplatedBody(serializedBody []byte) bool {
+	return bytes.Contains(serializedBody, []byte("{{"))
+}
+
+// parseResponseTemplate parses a serialized mock body once at load time so
+// each request only has to execute it, not re-parse it.
+func parseResponseTemplate(mock MockDefinition, serializedBody []byte) (*template.Template, error) {
+	return template.New(mock.Endpoint).Funcs(templateFuncs).Parse(string(serializedBody))
+}
+
+// renderResponseTemplate executes a cached template against the current
+// request and returns the rendered bytes.
+func renderResponseTemplate(tmpl *template.Template, r *http.Request) ([]byte, error) {
+	var body interface{}
+	if raw, err := readAndRestoreBody(r); err == nil && len(raw) > 0 {
+		_ = json.Unmarshal(raw, &body)
+	}
+
+	data := templateContext{
+		Path:       r.URL.Path,
+		PathParams: pathParamsFromContext(r.Context()),
+		Query:      map[string][]string(r.URL.Query()),
+		Headers:    map[string][]string(r.Header),
+		Body:       body,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pathParamsFromContext returns the path parameters captured during
+// routing, or an empty map when the request wasn't dispatched through a
+// parameterized route.
+func pathParamsFromContext(ctx context.Context) map[string]string {
+	if params, ok := ctx.Value(pathParamsContextKey).(map[string]string); ok {
+		return params
+	}
+	return map[string]string{}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce coalesces bursts of filesystem events (e.g. an editor
+// writing a file via rename+create) into a single reload.
+const reloadDebounce = 100 * time.Millisecond
+
+// watchMocks watches server.mockRootDirectory (and any subdirectories) for
+// changes and reloads server whenever a mock file is added, modified or
+// removed. The returned watcher must be closed by the caller; watching stops
+// when ctx is done.
+func watchMocks(ctx context.Context, server *Server) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addRecursive(watcher, server.mockRootDirectory); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		var debounce *time.Timer
+
+		for {
+			select {
+			case <-ctx.Done():
+				if debounce != nil {
+					debounce.Stop()
+				}
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := addRecursive(watcher, event.Name); err != nil {
+							log.Printf("[ERROR] failed to watch %s: %v", event.Name, err)
+						}
+					}
+				}
+
+				if filepath.Ext(event.Name) != ".json" {
+					continue
+				}
+
+				if debounce != nil {
+					debounce.Stop()
+				}
+				debounce = time.AfterFunc(reloadDebounce, func() {
+					log.Printf("detected change in %s, reloading mocks", event.Name)
+					if err := server.Reload(); err != nil {
+						log.Printf("[ERROR] failed to reload mocks: %v", err)
+					}
+				})
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("[ERROR] watcher error: %v", err)
+			}
+		}
+	}()
+
+	return watcher, nil
+}
+
+func addRecursive(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}